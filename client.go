@@ -0,0 +1,44 @@
+package main
+
+import (
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// restConfig -- Builds a rest.Config from the plugin's Kubernetes connection settings
+func (p Plugin) restConfig() *rest.Config {
+	return &rest.Config{
+		Host:        p.KubeConfig.Server,
+		BearerToken: p.KubeConfig.Token,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   []byte(p.KubeConfig.Ca),
+			Insecure: p.KubeConfig.InsecureSkipTLSVerify,
+		},
+	}
+}
+
+// CreateDynamicClient -- Creates a dynamic client plus a discovery-backed RESTMapper, so arbitrary
+// GVKs (including CRDs) can be resolved to their GroupVersionResource and scope at apply time. The
+// underlying discovery client is also returned, for callers (like Prune) that need to enumerate
+// resource types the manifest itself doesn't mention.
+func (p Plugin) CreateDynamicClient() (dynamic.Interface, meta.RESTMapper, discovery.DiscoveryInterface, error) {
+	config := p.restConfig()
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	return dynamicClient, mapper, discoveryClient, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// diffResult is the outcome of dry-running a single object against the live cluster state.
+type diffResult struct {
+	Kind string
+	Name string
+	Diff string
+}
+
+// DryRunDiff performs a server-side dry-run apply of obj and returns a unified diff between the
+// live object (empty if it does not exist yet) and what the apply would produce, without mutating
+// anything in the cluster.
+func DryRunDiff(ctx context.Context, dynamicClient dynamic.Interface, mapper meta.RESTMapper, namespace string, obj *unstructured.Unstructured) (*diffResult, error) {
+	gvk := obj.GroupVersionKind()
+	restMapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("resolve REST mapping for %s; %w", gvk, err)
+	}
+
+	resourceInterface := scopedResource(dynamicClient, restMapping, namespace, obj)
+
+	before, err := resourceInterface.Get(ctx, obj.GetName(), metaV1.GetOptions{})
+	if err != nil {
+		if !kubeErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("get live %s %q; %w", gvk.Kind, obj.GetName(), err)
+		}
+		before = nil
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s %q; %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	force := true
+	after, err := resourceInterface.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metaV1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+		DryRun:       []string{metaV1.DryRunAll},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dry-run apply %s %q; %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	beforeYaml, err := toYAML(stripServerManagedFields(before))
+	if err != nil {
+		return nil, err
+	}
+	afterYaml, err := toYAML(stripServerManagedFields(after))
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(beforeYaml),
+		B:        difflib.SplitLines(afterYaml),
+		FromFile: fmt.Sprintf("live/%s/%s", gvk.Kind, obj.GetName()),
+		ToFile:   fmt.Sprintf("planned/%s/%s", gvk.Kind, obj.GetName()),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("compute diff for %s %q; %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	return &diffResult{Kind: gvk.Kind, Name: obj.GetName(), Diff: text}, nil
+}
+
+// stripServerManagedFields removes fields the apiserver populates or mutates independently of the
+// applied spec (managedFields, resourceVersion, generation, creationTimestamp, status), so a no-op
+// apply diffs as empty instead of flagging churn the manifest had no part in.
+func stripServerManagedFields(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if obj == nil {
+		return nil
+	}
+
+	cleaned := obj.DeepCopy()
+	unstructured.RemoveNestedField(cleaned.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(cleaned.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(cleaned.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(cleaned.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(cleaned.Object, "status")
+	return cleaned
+}
+
+func toYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	yamlBytes, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return "", err
+	}
+	return string(yamlBytes), nil
+}
+
+func scopedResource(dynamicClient dynamic.Interface, restMapping *meta.RESTMapping, namespace string, obj *unstructured.Unstructured) dynamic.ResourceInterface {
+	resourceInterface := dynamicClient.Resource(restMapping.Resource)
+	if restMapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return resourceInterface
+	}
+
+	ns := obj.GetNamespace()
+	if ns == "" {
+		ns = namespace
+	}
+	return resourceInterface.Namespace(ns)
+}
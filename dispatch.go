@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// fieldManager identifies this plugin's ownership of fields it sets via server-side apply
+const fieldManager = "drone-plugin-kube"
+
+// ApplyUnstructured -- Creates or updates an arbitrary Kubernetes object via server-side apply. The
+// RESTMapper resolves the object's GroupVersionKind to a GroupVersionResource and scope, so any kind
+// known to the cluster -- built-in or CRD -- can be applied without plugin-side per-kind handling.
+func ApplyUnstructured(ctx context.Context, dynamicClient dynamic.Interface, mapper meta.RESTMapper, namespace string, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	restMapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("resolve REST mapping for %s; %w", gvk, err)
+	}
+
+	resourceInterface := dynamicClient.Resource(restMapping.Resource)
+	if restMapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = namespace
+		}
+		namespacedResourceInterface := resourceInterface.Namespace(ns)
+		return applyViaPatch(ctx, namespacedResourceInterface, gvk.Kind, obj)
+	}
+
+	return applyViaPatch(ctx, resourceInterface, gvk.Kind, obj)
+}
+
+func applyViaPatch(ctx context.Context, resourceInterface dynamic.ResourceInterface, kind string, obj *unstructured.Unstructured) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal %s %q; %w", kind, obj.GetName(), err)
+	}
+
+	force := true
+	_, err = resourceInterface.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metaV1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return fmt.Errorf("apply %s %q; %w", kind, obj.GetName(), err)
+	}
+
+	log.Printf("📦 Resource type: %s; applied %q", kind, obj.GetName())
+	return nil
+}
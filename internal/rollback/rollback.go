@@ -0,0 +1,215 @@
+// Package rollback restores a workload to its previous revision after a failed rollout, using the
+// same ReplicaSet/ControllerRevision history `kubectl rollout undo` relies on.
+package rollback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	replicaSetsGVR         = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	controllerRevisionsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "controllerrevisions"}
+)
+
+// revisionAnnotation is the annotation a Deployment's ReplicaSets carry their revision number in.
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// Rollback restores obj to its previous ready revision: for a Deployment, the pod template of the
+// prior ReplicaSet (equivalent to `kubectl rollout undo`); for a StatefulSet or DaemonSet, the data
+// of the owned ControllerRevision named by the object's status.currentRevision. That revision is the
+// prior one only while a rolling update is still in progress: once currentRevision has caught up
+// with the update, the patch re-applies the current revision, and an object whose status has no
+// currentRevision is not rolled back and an error is returned.
+func Rollback(ctx context.Context, dynamicClient dynamic.Interface, resource dynamic.ResourceInterface, live *unstructured.Unstructured) error {
+	switch live.GetKind() {
+	case "Deployment":
+		return rollbackDeployment(ctx, dynamicClient, resource, live)
+	case "StatefulSet", "DaemonSet":
+		return rollbackViaControllerRevision(ctx, dynamicClient, resource, live)
+	default:
+		return fmt.Errorf("rollback not supported for kind %q", live.GetKind())
+	}
+}
+
+func rollbackDeployment(ctx context.Context, dynamicClient dynamic.Interface, resource dynamic.ResourceInterface, live *unstructured.Unstructured) error {
+	currentRevision, err := revisionOf(live)
+	if err != nil {
+		return err
+	}
+
+	selector, err := readSelector(live)
+	if err != nil {
+		return err
+	}
+
+	replicaSets, err := dynamicClient.Resource(replicaSetsGVR).Namespace(live.GetNamespace()).List(ctx, metaV1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("list replicasets owned by %q; %w", live.GetName(), err)
+	}
+
+	previous, err := previousReplicaSet(replicaSets.Items, live.GetUID(), currentRevision)
+	if err != nil {
+		return fmt.Errorf("deployment %q: %w", live.GetName(), err)
+	}
+
+	template, found, err := unstructured.NestedMap(previous.Object, "spec", "template")
+	if err != nil || !found {
+		return fmt.Errorf("read pod template from replicaset %q; %w", previous.GetName(), err)
+	}
+	stripPodTemplateHash(template)
+
+	log.Printf("⛔️ Rolling back deployment %q to the pod template of replicaset %q", live.GetName(), previous.GetName())
+
+	if err := unstructured.SetNestedMap(live.Object, template, "spec", "template"); err != nil {
+		return fmt.Errorf("apply prior pod template to %q; %w", live.GetName(), err)
+	}
+
+	_, err = resource.Update(ctx, live, metaV1.UpdateOptions{})
+	return err
+}
+
+func rollbackViaControllerRevision(ctx context.Context, dynamicClient dynamic.Interface, resource dynamic.ResourceInterface, live *unstructured.Unstructured) error {
+	selector, err := readSelector(live)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := dynamicClient.Resource(controllerRevisionsGVR).Namespace(live.GetNamespace()).List(ctx, metaV1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("list controllerrevisions owned by %q; %w", live.GetName(), err)
+	}
+
+	currentRevisionName, found, err := unstructured.NestedString(live.Object, "status", "currentRevision")
+	if err != nil || !found || currentRevisionName == "" {
+		return fmt.Errorf("%s %q has no status.currentRevision to roll back to", live.GetKind(), live.GetName())
+	}
+
+	owned := ownedBy(revisions.Items, live.GetUID())
+
+	for _, revision := range owned {
+		if revision.GetName() != currentRevisionName {
+			continue
+		}
+
+		patch, found, err := unstructured.NestedMap(revision.Object, "data")
+		if err != nil || !found {
+			return fmt.Errorf("controllerrevision %q has no data to roll back to", revision.GetName())
+		}
+
+		log.Printf("⛔️ Rolling back %s %q to controllerrevision %q", live.GetKind(), live.GetName(), revision.GetName())
+
+		patchJSON, err := json.Marshal(patch)
+		if err != nil {
+			return fmt.Errorf("marshal controllerrevision %q data; %w", revision.GetName(), err)
+		}
+
+		_, err = resource.Patch(ctx, live.GetName(), types.StrategicMergePatchType, patchJSON, metaV1.PatchOptions{})
+		return err
+	}
+
+	return fmt.Errorf("%s %q: currentRevision %q not found among its controllerrevisions", live.GetKind(), live.GetName(), currentRevisionName)
+}
+
+// readSelector reads spec.selector as a full LabelSelector (matchLabels and matchExpressions alike)
+// and converts it to a labels.Selector, so rollback can find owned ReplicaSets/ControllerRevisions
+// even when the workload selects by matchExpressions rather than plain matchLabels.
+func readSelector(obj *unstructured.Unstructured) (labels.Selector, error) {
+	raw, found, err := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if err != nil {
+		return nil, fmt.Errorf("read selector for %s %q; %w", obj.GetKind(), obj.GetName(), err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%s %q has no spec.selector", obj.GetKind(), obj.GetName())
+	}
+
+	var labelSelector metaV1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &labelSelector); err != nil {
+		return nil, fmt.Errorf("decode selector for %s %q; %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	selector, err := metaV1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("convert selector for %s %q; %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return selector, nil
+}
+
+// stripPodTemplateHash removes the controller-owned pod-template-hash label from a pod template
+// copied out of a ReplicaSet, mirroring what `kubectl rollout undo` does before writing the prior
+// template back into the Deployment -- otherwise a controller-managed label leaks into its spec.
+func stripPodTemplateHash(template map[string]interface{}) {
+	unstructured.RemoveNestedField(template, "metadata", "labels", "pod-template-hash")
+}
+
+func revisionOf(obj *unstructured.Unstructured) (int, error) {
+	raw, ok := obj.GetAnnotations()[revisionAnnotation]
+	if !ok {
+		return 0, fmt.Errorf("%q has no %s annotation", obj.GetName(), revisionAnnotation)
+	}
+	return strconv.Atoi(raw)
+}
+
+// previousReplicaSet returns the ReplicaSet owned by ownerUID with the largest revision strictly
+// less than currentRevision.
+func previousReplicaSet(items []unstructured.Unstructured, ownerUID types.UID, currentRevision int) (*unstructured.Unstructured, error) {
+	var best *unstructured.Unstructured
+	bestRevision := -1
+
+	for i := range items {
+		replicaSet := &items[i]
+		if !isOwnedBy(replicaSet, ownerUID) {
+			continue
+		}
+
+		revision, err := revisionOf(replicaSet)
+		if err != nil || revision >= currentRevision {
+			continue
+		}
+
+		if revision > bestRevision {
+			bestRevision = revision
+			best = replicaSet
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no previous ready revision found")
+	}
+	return best, nil
+}
+
+func ownedBy(items []unstructured.Unstructured, uid types.UID) []*unstructured.Unstructured {
+	var owned []*unstructured.Unstructured
+	for i := range items {
+		if isOwnedBy(&items[i], uid) {
+			owned = append(owned, &items[i])
+		}
+	}
+	return owned
+}
+
+func isOwnedBy(obj *unstructured.Unstructured, uid types.UID) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
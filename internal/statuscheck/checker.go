@@ -0,0 +1,99 @@
+package statuscheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// pollInterval controls how often WaitAll re-fetches a not-yet-ready object.
+const pollInterval = 2 * time.Second
+
+// Result records the outcome of waiting for a single object to become ready.
+type Result struct {
+	Kind    string
+	Name    string
+	Ready   bool
+	Message string
+}
+
+// WaitAll polls every object in objs until each reaches a ready state or timeout elapses. Objects
+// whose kind has no registered Predicate are treated as ready immediately. It returns one Result
+// per object, in order, and a non-nil error for the first object that failed to become ready.
+//
+// Objects are waited on strictly sequentially under the single shared deadline above, so a slow
+// object can consume the whole timeout before later objects are ever polled. That matches waiting
+// on a manifest in apply order (each object gets a fair shot only once its predecessors are ready)
+// but means timeout should budget for the whole manifest, not any one object.
+func WaitAll(ctx context.Context, dynamicClient dynamic.Interface, mapper meta.RESTMapper, objs []*unstructured.Unstructured, timeout time.Duration) ([]Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]Result, len(objs))
+	var failed error
+
+	for i, obj := range objs {
+		result, err := waitOne(ctx, dynamicClient, mapper, obj)
+		results[i] = result
+		if err != nil && failed == nil {
+			failed = err
+		}
+	}
+
+	return results, failed
+}
+
+func waitOne(ctx context.Context, dynamicClient dynamic.Interface, mapper meta.RESTMapper, obj *unstructured.Unstructured) (Result, error) {
+	gvk := obj.GroupVersionKind()
+	result := Result{Kind: gvk.Kind, Name: obj.GetName()}
+
+	predicate := PredicateFor(gvk.Kind)
+	if predicate == nil {
+		result.Ready = true
+		return result, nil
+	}
+
+	restMapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return result, fmt.Errorf("resolve REST mapping for %s %q; %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	var resourceInterface dynamic.ResourceInterface = dynamicClient.Resource(restMapping.Resource)
+	if restMapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resourceInterface = dynamicClient.Resource(restMapping.Resource).Namespace(obj.GetNamespace())
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		live, err := resourceInterface.Get(ctx, obj.GetName(), metaV1.GetOptions{})
+		if err != nil {
+			return result, fmt.Errorf("get %s %q; %w", gvk.Kind, obj.GetName(), err)
+		}
+
+		ready, message, err := predicate(live)
+		if err != nil {
+			return result, fmt.Errorf("%s %q: %w", gvk.Kind, obj.GetName(), err)
+		}
+		if ready {
+			result.Ready = true
+			return result, nil
+		}
+
+		log.Printf("📦 %s %q not ready yet: %s", gvk.Kind, obj.GetName(), message)
+		result.Message = message
+
+		select {
+		case <-ctx.Done():
+			return result, fmt.Errorf("timed out waiting for %s %q: %s", gvk.Kind, obj.GetName(), message)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package statuscheck implements Helm-style readiness predicates for Kubernetes objects, so a
+// manifest containing a mix of kinds can be waited on as a whole rather than one kind at a time.
+package statuscheck
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Predicate reports whether a live object has reached a ready state for its kind. An empty message
+// accompanies a true result; a non-nil error means readiness could not be determined at all.
+type Predicate func(obj *unstructured.Unstructured) (ready bool, message string, err error)
+
+// predicates maps a resource Kind to the readiness check used for objects of that kind. Kinds with
+// no registered predicate (ConfigMap, Ingress, RBAC objects, ...) are considered ready as soon as
+// they apply successfully.
+var predicates = map[string]Predicate{
+	"Deployment":            deploymentReady,
+	"StatefulSet":           statefulSetReady,
+	"DaemonSet":             daemonSetReady,
+	"Job":                   jobReady,
+	"Pod":                   podReady,
+	"PersistentVolumeClaim": pvcReady,
+	"Service":               serviceReady,
+}
+
+// PredicateFor returns the readiness predicate registered for kind, or nil if the kind has none.
+func PredicateFor(kind string) Predicate {
+	return predicates[kind]
+}
+
+func deploymentReady(obj *unstructured.Unstructured) (bool, string, error) {
+	generation, _, _ := unstructured.NestedInt64(obj.Object, "metadata", "generation")
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observedGeneration < generation {
+		return false, "waiting for controller to observe latest spec", nil
+	}
+
+	specReplicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		specReplicas = 1
+	}
+
+	updatedReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+	if updatedReplicas < specReplicas {
+		return false, fmt.Sprintf("%d of %d replicas updated", updatedReplicas, specReplicas), nil
+	}
+
+	maxUnavailable, err := maxUnavailableFor(obj, specReplicas)
+	if err != nil {
+		return false, "", err
+	}
+
+	availableReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "availableReplicas")
+	if availableReplicas < specReplicas-maxUnavailable {
+		return false, fmt.Sprintf("%d of %d replicas available", availableReplicas, specReplicas), nil
+	}
+
+	return true, "", nil
+}
+
+// maxUnavailableFor resolves spec.strategy.rollingUpdate.maxUnavailable, which may be an int or a
+// percent string, against the deployment's desired replica count.
+func maxUnavailableFor(obj *unstructured.Unstructured, specReplicas int64) (int64, error) {
+	raw, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "strategy", "rollingUpdate", "maxUnavailable")
+	if !found {
+		return 0, nil
+	}
+
+	var intOrString intstr.IntOrString
+	switch v := raw.(type) {
+	case int64:
+		intOrString = intstr.FromInt(int(v))
+	case string:
+		intOrString = intstr.FromString(v)
+	default:
+		return 0, fmt.Errorf("unexpected maxUnavailable type %T", raw)
+	}
+
+	value, err := intstr.GetScaledValueFromIntOrPercent(&intOrString, int(specReplicas), true)
+	if err != nil {
+		return 0, err
+	}
+	return int64(value), nil
+}
+
+func statefulSetReady(obj *unstructured.Unstructured) (bool, string, error) {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+
+	readyReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	if readyReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas ready", readyReplicas, replicas), nil
+	}
+
+	updateRevision, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+	currentRevision, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+	if updateRevision != "" && updateRevision != currentRevision {
+		return false, "waiting for rolling update to finish", nil
+	}
+
+	return true, "", nil
+}
+
+func daemonSetReady(obj *unstructured.Unstructured) (bool, string, error) {
+	desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+	numberReady, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+	updatedNumberScheduled, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedNumberScheduled")
+
+	if numberReady < desired {
+		return false, fmt.Sprintf("%d of %d pods ready", numberReady, desired), nil
+	}
+	if updatedNumberScheduled < desired {
+		return false, fmt.Sprintf("%d of %d pods updated", updatedNumberScheduled, desired), nil
+	}
+
+	return true, "", nil
+}
+
+func jobReady(obj *unstructured.Unstructured) (bool, string, error) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch condition["type"] {
+		case "Complete":
+			if condition["status"] == "True" {
+				return true, "", nil
+			}
+		case "Failed":
+			if condition["status"] == "True" {
+				message, _ := condition["message"].(string)
+				return false, "", fmt.Errorf("job failed: %s", message)
+			}
+		}
+	}
+	return false, "waiting for job to complete", nil
+}
+
+func podReady(obj *unstructured.Unstructured) (bool, string, error) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		if condition["status"] == "True" {
+			return true, "", nil
+		}
+		reason, _ := condition["reason"].(string)
+		return false, reason, nil
+	}
+	return false, "waiting for Ready condition", nil
+}
+
+func pvcReady(obj *unstructured.Unstructured) (bool, string, error) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if phase == "Bound" {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("phase is %s", phase), nil
+}
+
+func serviceReady(obj *unstructured.Unstructured) (bool, string, error) {
+	serviceType, _, _ := unstructured.NestedString(obj.Object, "spec", "type")
+	if serviceType != "LoadBalancer" {
+		return true, "", nil
+	}
+
+	ingress, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+	if len(ingress) > 0 {
+		return true, "", nil
+	}
+	return false, "waiting for load balancer ingress", nil
+}
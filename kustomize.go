@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// buildKustomization runs `kustomize build` in-process against baseDir, optionally layered with an
+// overlay subdirectory, and returns the resulting multi-document YAML stream.
+func buildKustomization(baseDir string, overlay string) (string, error) {
+	target := baseDir
+	if overlay != "" {
+		target = filepath.Join(baseDir, overlay)
+	}
+
+	fSys := filesys.MakeFsOnDisk()
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+
+	resMap, err := kustomizer.Run(fSys, target)
+	if err != nil {
+		return "", fmt.Errorf("build kustomization %q; %w", target, err)
+	}
+
+	yamlBytes, err := resMap.AsYaml()
+	if err != nil {
+		return "", fmt.Errorf("serialize kustomize output; %w", err)
+	}
+
+	return string(yamlBytes), nil
+}
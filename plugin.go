@@ -4,20 +4,21 @@ import (
 	"context"
 	"errors"
 	"io/ioutil"
-	v1 "k8s.io/api/batch/v1"
-	"k8s.io/client-go/kubernetes"
 	"log"
-	"os"
-	"regexp"
 	"strings"
+	"time"
 
-	"github.com/aymerick/raymond"
-	appV1 "k8s.io/api/apps/v1"
-	coreV1 "k8s.io/api/core/v1"
-	v1BetaV1 "k8s.io/api/extensions/v1beta1"
-	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/ghua/drone-plugin-kube/internal/statuscheck"
 )
 
+// waitTimeout bounds how long Exec waits for applied resources to become ready
+const waitTimeout = 120 * time.Second
+
 type (
 	// KubeConfig -- Contains connection settings for Kube client
 	KubeConfig struct {
@@ -29,8 +30,17 @@ type (
 	}
 	// Plugin -- Contains config for plugin
 	Plugin struct {
-		Template   string
-		KubeConfig KubeConfig
+		Template          string
+		TemplateEngine    string
+		ValuesFiles       []string
+		RollbackOnFailure bool
+		Kustomize         string
+		KustomizeOverlay  string
+		DryRun            bool
+		FailOnDiff        bool
+		Prune             bool
+		Release           string
+		KubeConfig        KubeConfig
 	}
 )
 
@@ -45,120 +55,160 @@ func (p Plugin) Exec() error {
 	if p.KubeConfig.Ca == "" {
 		return errors.New("PLUGIN_CA is not defined")
 	}
-	if p.Template == "" {
-		return errors.New("PLUGIN_TEMPLATE, or template must be defined")
-	}
-	// Make map of environment variables set by Drone
-	envCtx := make(map[string]string)
-	pluginEnv := os.Environ()
-	for _, value := range pluginEnv {
-		re := regexp.MustCompile(`^PLUGIN_(.*)=(.*)`)
-		if re.MatchString(value) {
-			matches := re.FindStringSubmatch(value)
-			key := strings.ToLower(matches[1])
-			envCtx[key] = matches[2]
-		}
+	if p.Template == "" && p.Kustomize == "" {
+		return errors.New("PLUGIN_TEMPLATE or PLUGIN_KUSTOMIZE must be defined")
+	}
+	if p.Prune && p.Release == "" {
+		return errors.New("PLUGIN_RELEASE must be defined when prune is enabled")
+	}
 
-		re = regexp.MustCompile(`^DRONE_(.*)=(.*)`)
-		if re.MatchString(value) {
-			matches := re.FindStringSubmatch(value)
-			key := strings.ToLower(matches[1])
-			envCtx[key] = matches[2]
-		}
+	renderCtx, err := buildRenderContext(p.ValuesFiles)
+	if err != nil {
+		return err
 	}
 
-	// Grab template from filesystem
-	raw, err := ioutil.ReadFile(p.Template)
+	renderer, err := NewRenderer(p.TemplateEngine, renderCtx)
 	if err != nil {
-		log.Print("⛔️ Error reading template file:")
 		return err
 	}
 
+	// Kustomize mode builds the resource stream in-process instead of reading a single template
+	// file; either way the result is then layered with the configured renderer so Drone env vars
+	// (image tag, commit SHA, ...) can still be substituted in.
+	var raw string
+	if p.Kustomize != "" {
+		raw, err = buildKustomization(p.Kustomize, p.KustomizeOverlay)
+		if err != nil {
+			return err
+		}
+	} else {
+		rawBytes, err := ioutil.ReadFile(p.Template)
+		if err != nil {
+			log.Print("⛔️ Error reading template file:")
+			return err
+		}
+		raw = string(rawBytes)
+	}
+
 	// Parse template
-	templateYaml, err := raymond.Render(string(raw), envCtx)
+	templateYaml, err := renderer.Render(raw)
 	if err != nil {
 		return err
 	}
 
 	// Connect to Kubernetes
-	clientset, err := p.CreateKubeClient()
+	dynamicClient, mapper, discoveryClient, err := p.CreateDynamicClient()
 	if err != nil {
 		return err
 	}
 
-	templateYamlParts := strings.Split(templateYaml, "---")
-	for _, templateYamlPart := range templateYamlParts {
-		templateYamlPart = strings.TrimSpace(templateYamlPart)
-		if len(templateYamlPart) == 0 {
-			continue
+	ctx := context.Background()
+
+	objs, err := p.decodeManifest(templateYaml)
+	if err != nil {
+		return err
+	}
+
+	if p.DryRun {
+		return p.runDryRun(ctx, dynamicClient, mapper, objs)
+	}
+
+	var applied []*unstructured.Unstructured
+	for _, obj := range objs {
+		namespace := p.KubeConfig.Namespace
+		if namespace == "" {
+			namespace = obj.GetNamespace()
 		}
 
-		err = p.handleYamlConfig(templateYamlPart, clientset)
-		if err != nil {
+		if err := ApplyUnstructured(ctx, dynamicClient, mapper, namespace, obj); err != nil {
 			return err
 		}
+		applied = append(applied, obj)
 	}
 
-	return nil
-}
+	if p.Prune {
+		if err := Prune(ctx, dynamicClient, discoveryClient, mapper, p.KubeConfig.Namespace, p.Release, applied); err != nil {
+			return err
+		}
+	}
 
-func (p Plugin) handleYamlConfig(templateYaml string, clientset *kubernetes.Clientset) error {
-	// Decode
-	kubernetesObject, _, err := scheme.Codecs.UniversalDeserializer().Decode([]byte(templateYaml), nil, nil)
-	if err != nil {
-		log.Print("⛔️ Error decoding template into valid Kubernetes object:")
-		return err
+	log.Print("📦 Waiting for applied resources to become ready.")
+	results, waitErr := statuscheck.WaitAll(ctx, dynamicClient, mapper, applied, waitTimeout)
+	for _, result := range results {
+		if result.Ready {
+			log.Printf("📦 %s %q is ready", result.Kind, result.Name)
+		}
 	}
 
-	ctx := context.Background()
+	if waitErr != nil && p.RollbackOnFailure {
+		return p.rollbackAndRewait(ctx, dynamicClient, mapper, applied, results, waitTimeout, waitErr)
+	}
 
-	switch o := kubernetesObject.(type) {
-	case *appV1.Deployment:
-		log.Print("📦 Resource type: Deployment")
-		if p.KubeConfig.Namespace == "" {
-			p.KubeConfig.Namespace = o.Namespace
+	return waitErr
+}
+
+// decodeManifest splits rendered YAML into its constituent documents and decodes each into an
+// unstructured object, resolving the namespace fallback and (when prune is enabled) stamping the
+// release labels Prune later looks for.
+func (p Plugin) decodeManifest(templateYaml string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+
+	for _, templateYamlPart := range strings.Split(templateYaml, "---") {
+		templateYamlPart = strings.TrimSpace(templateYamlPart)
+		if len(templateYamlPart) == 0 {
+			continue
 		}
 
-		err = CreateOrUpdateDeployment(ctx, clientset, p.KubeConfig.Namespace, o)
-		if err != nil {
-			return err
+		obj := &unstructured.Unstructured{}
+		decoder := yamlutil.NewYAMLOrJSONDecoder(strings.NewReader(templateYamlPart), len(templateYamlPart))
+		if err := decoder.Decode(obj); err != nil {
+			log.Print("⛔️ Error decoding template into valid Kubernetes object:")
+			return nil, err
 		}
 
-		// Watch for successful update
-		log.Print("📦 Watching deployment until no unavailable replicas.")
-		state, watchErr := waitUntilDeploymentSettled(ctx, clientset, p.KubeConfig.Namespace, o.ObjectMeta.Name, 120)
-		log.Printf("%s", state)
-		return watchErr
-	case *coreV1.ConfigMap:
-		if p.KubeConfig.Namespace == "" {
-			p.KubeConfig.Namespace = o.Namespace
+		if p.KubeConfig.Namespace != "" {
+			obj.SetNamespace(p.KubeConfig.Namespace)
 		}
 
-		log.Print("📦 Resource type: ConfigMap")
-		err = ApplyConfigMap(ctx, clientset, p.KubeConfig.Namespace, o)
-	case *coreV1.Service:
-		if p.KubeConfig.Namespace == "" {
-			p.KubeConfig.Namespace = o.Namespace
+		if p.Prune {
+			labelForPrune(obj, p.Release)
 		}
 
-		log.Print("Resource type: Service")
-		err = ApplyService(ctx, clientset, p.KubeConfig.Namespace, o)
-	case *v1BetaV1.Ingress:
-		if p.KubeConfig.Namespace == "" {
-			p.KubeConfig.Namespace = o.Namespace
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+// runDryRun performs a server-side dry-run apply of every object and prints a unified diff of what
+// would change, without mutating the cluster. With fail_on_diff set, any non-empty diff fails the
+// build, giving PR pipelines a "plan" step similar to `terraform plan`.
+func (p Plugin) runDryRun(ctx context.Context, dynamicClient dynamic.Interface, mapper meta.RESTMapper, objs []*unstructured.Unstructured) error {
+	anyDiff := false
+
+	for _, obj := range objs {
+		namespace := p.KubeConfig.Namespace
+		if namespace == "" {
+			namespace = obj.GetNamespace()
 		}
 
-		log.Print("Resource type: Ingress")
-		err = ApplyIngress(ctx, clientset, p.KubeConfig.Namespace, o)
-	case *v1.CronJob:
-		if p.KubeConfig.Namespace == "" {
-			p.KubeConfig.Namespace = o.Namespace
+		result, err := DryRunDiff(ctx, dynamicClient, mapper, namespace, obj)
+		if err != nil {
+			return err
 		}
-		log.Print("Resource type: CronJob")
-		err = ApplyCronJob(ctx, clientset, p.KubeConfig.Namespace, o)
-	default:
-		return errors.New("⛔️ This plugin doesn't support that resource type")
+
+		if result.Diff == "" {
+			log.Printf("📦 %s %q: no changes", result.Kind, result.Name)
+			continue
+		}
+
+		anyDiff = true
+		log.Printf("📦 %s %q:\n%s", result.Kind, result.Name, result.Diff)
 	}
 
-	return err
+	if anyDiff && p.FailOnDiff {
+		return errors.New("dry run detected changes and fail_on_diff is set")
+	}
+
+	return nil
 }
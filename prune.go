@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "drone-plugin-kube"
+	releaseLabel   = "drone.io/release"
+)
+
+// labelForPrune stamps obj with the labels Prune later uses to find objects belonging to this
+// pipeline's release, so resources removed from the manifest can be found and deleted.
+func labelForPrune(obj *unstructured.Unstructured, release string) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	objLabels[managedByLabel] = managedByValue
+	objLabels[releaseLabel] = release
+	obj.SetLabels(objLabels)
+}
+
+// Prune deletes objects carrying this release's labels that were not present in applied, closing
+// the gap where removing a resource from the template otherwise leaves it orphaned in the cluster.
+// Candidate resource types are discovered from the cluster's full API surface rather than from
+// applied's kinds, so pruning still finds an orphan even when every object of its kind was removed
+// from the manifest (e.g. the Service was deleted but the Deployment stayed). The keep set is keyed
+// by group and resource rather than Kind, since dynamic.List doesn't populate TypeMeta on the objects
+// it returns; the version is left out because discovery lists every served version of a resource,
+// and the same object seen through another version must still count as kept. Cluster-scoped objects
+// are keyed with an empty namespace, as they are listed.
+func Prune(ctx context.Context, dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, mapper meta.RESTMapper, namespace string, release string, applied []*unstructured.Unstructured) error {
+	keep := make(map[string]bool, len(applied))
+	for _, obj := range applied {
+		ns := namespace
+		if ns == "" {
+			ns = obj.GetNamespace()
+		}
+
+		gvk := obj.GroupVersionKind()
+		restMapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("resolve REST mapping for %s %q; %w", gvk.Kind, obj.GetName(), err)
+		}
+
+		if restMapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			ns = ""
+		}
+
+		keep[restMapping.Resource.GroupResource().String()+"/"+ns+"/"+obj.GetName()] = true
+	}
+
+	resources, err := prunableResources(discoveryClient)
+	if err != nil {
+		return err
+	}
+
+	selector := fmt.Sprintf("%s=%s,%s=%s", managedByLabel, managedByValue, releaseLabel, release)
+
+	for _, candidate := range resources {
+		resourceInterface := dynamicClient.Resource(candidate.gvr)
+		listInterface := dynamic.ResourceInterface(resourceInterface)
+		if candidate.namespaced {
+			listInterface = resourceInterface.Namespace(namespace)
+		}
+
+		list, err := listInterface.List(ctx, metaV1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("list %s for pruning; %w", candidate.gvr.Resource, err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			// obj.GetKind() is empty here -- client-go's dynamic List doesn't populate per-item
+			// TypeMeta -- so key on the candidate's already-known group and resource instead of the
+			// object's kind.
+			key := candidate.gvr.GroupResource().String() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+			if keep[key] {
+				continue
+			}
+
+			log.Printf("📦 Pruning %s %q (no longer present in manifest)", obj.GetKind(), obj.GetName())
+
+			deleteInterface := dynamic.ResourceInterface(resourceInterface)
+			if candidate.namespaced {
+				deleteInterface = resourceInterface.Namespace(obj.GetNamespace())
+			}
+			if err := deleteInterface.Delete(ctx, obj.GetName(), metaV1.DeleteOptions{}); err != nil {
+				return fmt.Errorf("delete %s %q; %w", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+type prunableResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// prunableResources enumerates every resource type the cluster exposes that supports "list" and
+// "delete", independent of what kinds the current manifest happens to contain.
+func prunableResources(discoveryClient discovery.DiscoveryInterface) ([]prunableResource, error) {
+	_, apiResourceLists, err := discoveryClient.ServerGroupsAndResources()
+	if err != nil && apiResourceLists == nil {
+		return nil, fmt.Errorf("discover API resources for pruning; %w", err)
+	}
+
+	var resources []prunableResource
+	for _, list := range apiResourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, apiResource := range list.APIResources {
+			if strings.Contains(apiResource.Name, "/") {
+				continue // skip subresources, e.g. deployments/status
+			}
+			if !hasVerb(apiResource.Verbs, "list") || !hasVerb(apiResource.Verbs, "delete") {
+				continue
+			}
+
+			resources = append(resources, prunableResource{
+				gvr:        gv.WithResource(apiResource.Name),
+				namespaced: apiResource.Namespaced,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func hasVerb(verbs metaV1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package main
+
+import "fmt"
+
+// Renderer renders a raw template string (a Kubernetes manifest, a Kustomize overlay, ...) against
+// a RenderContext into plain YAML.
+type Renderer interface {
+	Render(raw string) (string, error)
+}
+
+// RenderContext is the structured data exposed to templates, replacing the old flat map of
+// lowercased PLUGIN_*/DRONE_* environment variables.
+type RenderContext struct {
+	// Env holds PLUGIN_* settings, lowercased and with the prefix stripped.
+	Env map[string]string
+	// Drone holds the handful of DRONE_* build variables templates commonly need.
+	Drone DroneContext
+	// DroneEnv holds every DRONE_* variable, lowercased and with the prefix stripped, matching the
+	// plugin's original flat-map behavior. The default (Handlebars) engine renders against this.
+	DroneEnv map[string]string
+	// Values is the result of merging values_files, for parameterizing deploys without
+	// abusing environment variables.
+	Values map[string]interface{}
+}
+
+// DroneContext exposes commonly used DRONE_* build variables as typed fields.
+type DroneContext struct {
+	Repo         string
+	Branch       string
+	Commit       string
+	CommitAuthor string
+	Tag          string
+	BuildNumber  string
+	BuildEvent   string
+}
+
+// NewRenderer returns the Renderer for the named template_engine setting. An empty engine
+// defaults to "handlebars", matching the plugin's original behavior.
+func NewRenderer(engine string, ctx *RenderContext) (Renderer, error) {
+	switch engine {
+	case "", "handlebars":
+		return &handlebarsRenderer{ctx}, nil
+	case "gotemplate":
+		return &goTemplateRenderer{ctx}, nil
+	case "jsonnet":
+		return &jsonnetRenderer{ctx}, nil
+	default:
+		return nil, fmt.Errorf("unknown template_engine %q", engine)
+	}
+}
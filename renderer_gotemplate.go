@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// goTemplateRenderer renders with Go's text/template plus Sprig's FuncMap, matching Helm's
+// templating dialect so existing chart templates work against this plugin too.
+type goTemplateRenderer struct {
+	ctx *RenderContext
+}
+
+func (r *goTemplateRenderer) Render(raw string) (string, error) {
+	tmpl, err := template.New("manifest").Funcs(sprig.TxtFuncMap()).Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r.ctx); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
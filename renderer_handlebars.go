@@ -0,0 +1,27 @@
+package main
+
+import "github.com/aymerick/raymond"
+
+// handlebarsRenderer is the default engine, preserving the plugin's original Handlebars behavior.
+type handlebarsRenderer struct {
+	ctx *RenderContext
+}
+
+func (r *handlebarsRenderer) Render(raw string) (string, error) {
+	return raymond.Render(raw, flattenContext(r.ctx))
+}
+
+// flattenContext reproduces the plugin's original flat map of lowercased PLUGIN_*/DRONE_* keys
+// (with the prefix stripped), so existing Handlebars templates keep working unchanged under the
+// default engine -- e.g. {{commit_sha}} and {{commit_branch}} still resolve, not just the handful
+// of names the structured Drone context exposes.
+func flattenContext(ctx *RenderContext) map[string]string {
+	flat := make(map[string]string, len(ctx.Env)+len(ctx.DroneEnv))
+	for k, v := range ctx.Env {
+		flat[k] = v
+	}
+	for k, v := range ctx.DroneEnv {
+		flat[k] = v
+	}
+	return flat
+}
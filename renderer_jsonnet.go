@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"sigs.k8s.io/yaml"
+)
+
+// jsonnetRenderer evaluates the template as a Jsonnet snippet, exposing the render context as the
+// top-level external variable "ctx", and emits a stream of YAML documents.
+type jsonnetRenderer struct {
+	ctx *RenderContext
+}
+
+func (r *jsonnetRenderer) Render(raw string) (string, error) {
+	ctxJSON, err := json.Marshal(r.ctx)
+	if err != nil {
+		return "", fmt.Errorf("marshal render context; %w", err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("ctx", string(ctxJSON))
+
+	evaluated, err := vm.EvaluateAnonymousSnippet("manifest.jsonnet", raw)
+	if err != nil {
+		return "", fmt.Errorf("evaluate jsonnet; %w", err)
+	}
+
+	var docs []json.RawMessage
+	if err := json.Unmarshal([]byte(evaluated), &docs); err != nil {
+		// A single top-level object, rather than an array of documents, is also valid output.
+		docs = []json.RawMessage{json.RawMessage(evaluated)}
+	}
+
+	rendered := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		docYaml, err := yaml.JSONToYAML(doc)
+		if err != nil {
+			return "", fmt.Errorf("convert jsonnet output to YAML; %w", err)
+		}
+		rendered = append(rendered, string(docYaml))
+	}
+
+	return strings.Join(rendered, "---\n"), nil
+}
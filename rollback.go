@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/ghua/drone-plugin-kube/internal/rollback"
+	"github.com/ghua/drone-plugin-kube/internal/statuscheck"
+)
+
+// rollbackKinds are the workload kinds this plugin knows how to roll back via revision history.
+var rollbackKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+// rollbackAndRewait rolls back every failed object whose kind supports rollback, re-waits for the
+// rolled-back objects to settle, and then returns originalErr regardless of the rollback outcome --
+// the rollout itself failed, so the build should too.
+func (p Plugin) rollbackAndRewait(ctx context.Context, dynamicClient dynamic.Interface, mapper meta.RESTMapper, applied []*unstructured.Unstructured, results []statuscheck.Result, timeout time.Duration, originalErr error) error {
+	byKey := make(map[string]*unstructured.Unstructured, len(applied))
+	for _, obj := range applied {
+		byKey[obj.GetKind()+"/"+obj.GetName()] = obj
+	}
+
+	var rolledBack []*unstructured.Unstructured
+	for _, result := range results {
+		if result.Ready || !rollbackKinds[result.Kind] {
+			continue
+		}
+
+		obj := byKey[result.Kind+"/"+result.Name]
+		if obj == nil {
+			continue
+		}
+
+		restMapping, err := mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+		if err != nil {
+			log.Printf("⛔️ Rollback skipped for %s %q: %v", result.Kind, result.Name, err)
+			continue
+		}
+		resourceInterface := dynamicClient.Resource(restMapping.Resource).Namespace(obj.GetNamespace())
+
+		live, err := resourceInterface.Get(ctx, obj.GetName(), metaV1.GetOptions{})
+		if err != nil {
+			log.Printf("⛔️ Rollback skipped for %s %q: %v", result.Kind, result.Name, err)
+			continue
+		}
+
+		if err := rollback.Rollback(ctx, dynamicClient, resourceInterface, live); err != nil {
+			log.Printf("⛔️ Rollback failed for %s %q: %v", result.Kind, result.Name, err)
+			continue
+		}
+
+		rolledBack = append(rolledBack, live)
+	}
+
+	if len(rolledBack) > 0 {
+		log.Print("📦 Re-checking readiness after rollback.")
+		if _, waitErr := statuscheck.WaitAll(ctx, dynamicClient, mapper, rolledBack, timeout); waitErr != nil {
+			log.Printf("⛔️ Resources still not ready after rollback: %v", waitErr)
+		}
+	}
+
+	return fmt.Errorf("rollout failed, attempted rollback to previous revision; %w", originalErr)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// buildRenderContext assembles the structured RenderContext a Renderer sees: PLUGIN_* settings
+// under Env, common DRONE_* build variables under Drone (plus the full set under DroneEnv, for
+// the default engine's flat-map behavior), and valuesFiles merged under Values.
+func buildRenderContext(valuesFiles []string) (*RenderContext, error) {
+	env := make(map[string]string)
+	droneEnv := make(map[string]string)
+	for _, kv := range os.Environ() {
+		switch {
+		case strings.HasPrefix(kv, "PLUGIN_"):
+			parts := strings.SplitN(strings.TrimPrefix(kv, "PLUGIN_"), "=", 2)
+			if len(parts) == 2 {
+				env[strings.ToLower(parts[0])] = parts[1]
+			}
+		case strings.HasPrefix(kv, "DRONE_"):
+			parts := strings.SplitN(strings.TrimPrefix(kv, "DRONE_"), "=", 2)
+			if len(parts) == 2 {
+				droneEnv[strings.ToLower(parts[0])] = parts[1]
+			}
+		}
+	}
+
+	drone := DroneContext{
+		Repo:         os.Getenv("DRONE_REPO"),
+		Branch:       os.Getenv("DRONE_COMMIT_BRANCH"),
+		Commit:       os.Getenv("DRONE_COMMIT_SHA"),
+		CommitAuthor: os.Getenv("DRONE_COMMIT_AUTHOR"),
+		Tag:          os.Getenv("DRONE_TAG"),
+		BuildNumber:  os.Getenv("DRONE_BUILD_NUMBER"),
+		BuildEvent:   os.Getenv("DRONE_BUILD_EVENT"),
+	}
+
+	values, err := loadValuesFiles(valuesFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderContext{Env: env, Drone: drone, DroneEnv: droneEnv, Values: values}, nil
+}
+
+// loadValuesFiles reads and merges each YAML file in paths into a single map, later files taking
+// precedence over earlier ones. Only top-level keys are merged: a later file's key replaces the
+// earlier value whole, nested maps included.
+func loadValuesFiles(paths []string) (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+	for _, path := range paths {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read values file %q; %w", path, err)
+		}
+
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(raw, &values); err != nil {
+			return nil, fmt.Errorf("parse values file %q; %w", path, err)
+		}
+
+		for k, v := range values {
+			merged[k] = v
+		}
+	}
+
+	return merged, nil
+}